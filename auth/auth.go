@@ -1,79 +1,291 @@
 package auth
 
 import (
-	
+	"context"
 	"errors"
-	"sync"
-	"golang.org/x/crypto/bcrypt"
+	"time"
+
+	"github.com/MetiTamru/auth-with-test-golang-project/auth/store"
 )
 
+// AuthConfig configures how an AuthService signs and verifies JWTs.
+type AuthConfig struct {
+	// Keys holds the active signing key plus every key still accepted for
+	// verification, so outstanding tokens keep validating across rotation.
+	Keys *KeySet
+	// Issuer and Audience populate the iss/aud claims on issued tokens. Both
+	// are optional; when set, VerifyToken also checks them on incoming tokens.
+	Issuer   string
+	Audience string
+	// TokenTTL is how long an issued token is valid for. Defaults to 1 hour.
+	TokenTTL time.Duration
+}
+
+func (c AuthConfig) withDefaults() AuthConfig {
+	if c.TokenTTL == 0 {
+		c.TokenTTL = time.Hour
+	}
+	return c
+}
+
 type AuthService interface {
-	Register(username, password string) error
-	Login(username, password string) (string, error)
+	Register(ctx context.Context, username, password string) error
+	Login(ctx context.Context, username, password string) (string, error)
+	VerifyToken(token string) (*Claims, error)
+	// Revoke invalidates a single issued token, identified by its jti.
+	Revoke(tokenID string) error
+	// RevokeAllForUser invalidates every token issued to username, e.g. on
+	// a server-side logout-everywhere request.
+	RevokeAllForUser(username string) error
+	// AddCredential attaches an additional credential (e.g. an API token or
+	// OAuth link) to an already-registered user.
+	AddCredential(ctx context.Context, username string, c Credential) error
+	// RemoveCredential detaches a credential by the id returned from
+	// ListCredentials.
+	RemoveCredential(ctx context.Context, username, credentialID string) error
+	// ListCredentials describes every credential a user has, without secrets.
+	ListCredentials(ctx context.Context, username string) ([]CredentialMeta, error)
+	// LoginWith authenticates with any Credential kind the user has
+	// registered, dispatching to the matching verifier.
+	LoginWith(ctx context.Context, c Credential) (string, error)
 }
 
 type authService struct {
-	users map[string]string 
-	mu    sync.RWMutex
+	repo    store.UserRepository
+	config  AuthConfig
+	hasher  PasswordHasher
+	jar     TokenJar
+	limiter LoginLimiter
 }
 
-func NewAuthService() AuthService {
+// NewAuthService builds an AuthService that issues and verifies JWTs signed
+// according to cfg. cfg.Keys must not be nil. By default passwords are
+// hashed with bcrypt, stored in an in-memory UserRepository, issued tokens
+// are tracked in an in-memory TokenJar, and logins are throttled by an
+// in-memory LoginLimiter; pass WithHasher/WithBcryptCost, WithRepository,
+// WithTokenJar, or WithLimiter to override any of those.
+func NewAuthService(cfg AuthConfig, opts ...Option) AuthService {
 	authService := &authService{
-		users: make(map[string]string),
+		repo:    store.NewMemoryStore(),
+		config:  cfg.withDefaults(),
+		hasher:  NewBcryptHasher(),
+		jar:     NewMemoryTokenJar(),
+		limiter: NewLoginLimiter(LimiterConfig{}),
+	}
+
+	for _, opt := range opts {
+		opt(authService)
 	}
+
 	return authService
 }
 
-func (a *authService) Register(username, password string) error {
+func (a *authService) Register(ctx context.Context, username, password string) error {
 
 	if username == "" || password == "" {
 		return errors.New("username and password cannot be empty")
 	}
 
-	a.mu.Lock()
-	defer a.mu.Unlock()
+	record, err := a.toRecord(PasswordCredential{Username: username, Password: password})
+	if err != nil {
+		return err
+	}
+
+	if err := a.repo.Create(ctx, store.User{Username: username, Credentials: []store.CredentialRecord{record}}); err != nil {
+		if errors.Is(err, store.ErrUserExists) {
+			return errors.New("username already exist")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (a *authService) Login(ctx context.Context, username, password string) (string, error) {
+
+	if username == "" || password == "" {
+		return "", errors.New("username and password cannot be empty")
+	}
+
+	ip := IPFromContext(ctx)
+	if allowed, retryAfter := a.limiter.Allow(username, ip); !allowed {
+		return "", &RateLimitedError{RetryAfter: retryAfter}
+	}
 
+	user, err := a.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			a.limiter.RecordFailure(username, ip)
+			return "", errors.New("this user does not exist")
+		}
+		return "", err
+	}
 
-	if _, ok := a.users[username]; 
-	ok {
-		return errors.New("username already exist")
+	records := findCredentials(user, CredentialKindPassword)
+	if len(records) == 0 {
+		a.limiter.RecordFailure(username, ip)
+		return "", ErrCredentialKindMismatch
 	}
 
+	if !a.verifyAny(PasswordCredential{Username: username, Password: password}, records) {
+		a.limiter.RecordFailure(username, ip)
+		return "", errors.New("invalid password")
+	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	a.limiter.RecordSuccess(username, ip)
+	return a.issueTokenFor(username)
+}
+
+// LoginWith authenticates with any Credential kind the user has registered.
+func (a *authService) LoginWith(ctx context.Context, c Credential) (string, error) {
+	username := c.Owner()
+	if username == "" {
+		return "", errors.New("username and password cannot be empty")
+	}
+
+	ip := IPFromContext(ctx)
+	if allowed, retryAfter := a.limiter.Allow(username, ip); !allowed {
+		return "", &RateLimitedError{RetryAfter: retryAfter}
+	}
+
+	user, err := a.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			a.limiter.RecordFailure(username, ip)
+			return "", errors.New("this user does not exist")
+		}
+		return "", err
+	}
+
+	records := findCredentials(user, c.Kind())
+	if len(records) == 0 {
+		a.limiter.RecordFailure(username, ip)
+		return "", ErrCredentialKindMismatch
+	}
+
+	if !a.verifyAny(c, records) {
+		a.limiter.RecordFailure(username, ip)
+		return "", errors.New("invalid password")
+	}
+
+	a.limiter.RecordSuccess(username, ip)
+	return a.issueTokenFor(username)
+}
+
+// AddCredential attaches an additional credential to an already-registered
+// user.
+func (a *authService) AddCredential(ctx context.Context, username string, c Credential) error {
+	record, err := a.toRecord(c)
 	if err != nil {
 		return err
 	}
 
+	if err := a.repo.AddCredential(ctx, username, record); err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return errors.New("this user does not exist")
+		}
+		return err
+	}
 
-	a.users[username] = string(hashedPassword)
 	return nil
 }
- 
 
-func (a *authService) Login(username, password string) (string, error) {
+// RemoveCredential detaches a credential by the id returned from
+// ListCredentials.
+func (a *authService) RemoveCredential(ctx context.Context, username, credentialID string) error {
+	if err := a.repo.RemoveCredential(ctx, username, credentialID); err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return errors.New("this user does not exist")
+		}
+		if errors.Is(err, store.ErrCredentialNotFound) {
+			return errors.New("credential does not exist")
+		}
+		return err
+	}
 
-	if username == "" || password == "" {
-		return "", errors.New("username and password cannot be empty")
+	return nil
+}
+
+// ListCredentials describes every credential a user has, without secrets.
+func (a *authService) ListCredentials(ctx context.Context, username string) ([]CredentialMeta, error) {
+	user, err := a.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, errors.New("this user does not exist")
+		}
+		return nil, err
 	}
 
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	metas := make([]CredentialMeta, 0, len(user.Credentials))
+	for _, record := range user.Credentials {
+		metas = append(metas, toCredentialMeta(record))
+	}
 
+	return metas, nil
+}
 
-	hashedPassword, ok := a.users[username]
-	if !ok {
-		return "", errors.New("this user does not exist")
+// issueTokenFor signs and records a new token for username.
+func (a *authService) issueTokenFor(username string) (string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
 	}
 
+	now := time.Now()
+	expiresAt := now.Add(a.config.TokenTTL)
+	claims := Claims{
+		Sub: username,
+		Iat: now.Unix(),
+		Exp: expiresAt.Unix(),
+		Iss: a.config.Issuer,
+		Aud: a.config.Audience,
+		Jti: tokenID,
+	}
 
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	token, err := issueToken(a.config.Keys, claims)
 	if err != nil {
-		return "", errors.New("invalid password")
+		return "", err
+	}
+
+	if err := a.jar.Put(TokenRecord{
+		ID:        tokenID,
+		Subject:   username,
+		IssuedAt:  now,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return "", err
 	}
 
-	
-	
-	token := "jwt_token_for_" + username
 	return token, nil
-}
\ No newline at end of file
+}
+
+// VerifyToken validates token's signature, expiry, issuer, and audience,
+// checks that it has not been revoked, and returns its claims.
+func (a *authService) VerifyToken(token string) (*Claims, error) {
+	claims, err := parseAndVerifyToken(a.config.Keys, token, time.Now(), a.config.Issuer, a.config.Audience)
+	if err != nil {
+		return claims, err
+	}
+
+	if claims.Jti != "" {
+		record, ok, err := a.jar.Get(claims.Jti)
+		if err != nil {
+			return claims, err
+		}
+		if ok && record.Revoked {
+			return claims, ErrTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
+
+// Revoke invalidates a single issued token, identified by its jti.
+func (a *authService) Revoke(tokenID string) error {
+	return a.jar.Revoke(tokenID)
+}
+
+// RevokeAllForUser invalidates every token issued to username.
+func (a *authService) RevokeAllForUser(username string) error {
+	return a.jar.RevokeAllForUser(username)
+}
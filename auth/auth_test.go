@@ -1,20 +1,55 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var testCtx = context.Background()
+
+func mustLogin(t *testing.T, user AuthService, username, password string) string {
+	t.Helper()
+
+	user.Register(testCtx, username, password)
+	token, err := user.Login(testCtx, username, password)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	return token
+}
+
+func testConfig() AuthConfig {
+	return AuthConfig{
+		Keys:     NewKeySet(NewHMACSigner("test-key", []byte("test-secret"))),
+		Issuer:   "auth-test",
+		Audience: "auth-test-clients",
+		TokenTTL: time.Hour,
+	}
+}
+
+// newTestAuthService builds an AuthService from testConfig with the cheapest
+// bcrypt cost, so tests exercising many Register/Login calls don't pay
+// bcrypt's real cost. Pass additional options to override the default, e.g.
+// WithHasher for a test that asserts on hasher calls directly.
+func newTestAuthService(opts ...Option) AuthService {
+	opts = append([]Option{WithBcryptCost(bcrypt.MinCost)}, opts...)
+	return NewAuthService(testConfig(), opts...)
+}
+
 func TestRegister(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("a new user should be registered successfully", func(t *testing.T) {
 
-		user := NewAuthService()
+		user := newTestAuthService()
 
-		err := user.Register("meti", "meti1234")
+		err := user.Register(ctx, "meti", "meti1234")
 
 		if err != nil {
 			t.Errorf("expected no error but got %v", err)
@@ -22,9 +57,9 @@ func TestRegister(t *testing.T) {
 	})
 
 	t.Run("should return error for empty username", func(t *testing.T) {
-		user := NewAuthService()
+		user := newTestAuthService()
 
-		err := user.Register("", "password123")
+		err := user.Register(ctx, "", "password123")
 
 		if err == nil {
 			t.Fatal("expected error but got none")
@@ -36,9 +71,9 @@ func TestRegister(t *testing.T) {
 	})
 
 	t.Run("should return error for empty password", func(t *testing.T) {
-		user := NewAuthService()
+		user := newTestAuthService()
 
-		err := user.Register("username", "")
+		err := user.Register(ctx, "username", "")
 
 		if err == nil {
 			t.Fatal("expected error but got none")
@@ -51,14 +86,14 @@ func TestRegister(t *testing.T) {
 
 	t.Run("should return error for existing username", func(t *testing.T) {
 
-		user := NewAuthService()
+		user := newTestAuthService()
 
-		err := user.Register("meti", "meti1234")
+		err := user.Register(ctx, "meti", "meti1234")
 		if err != nil {
 			t.Fatalf("first registration failed: %v", err)
 		}
 
-		err = user.Register("meti", "qwerty")
+		err = user.Register(ctx, "meti", "qwerty")
 
 		if err == nil {
 			t.Fatal("expected error for duplicate username but got none")
@@ -70,37 +105,43 @@ func TestRegister(t *testing.T) {
 	})
 
 	t.Run("should handle special characters in username and password", func(t *testing.T) {
-		user := NewAuthService()
+		user := newTestAuthService()
 
 		username := "meti@gmail.com"
 		password := "@meti##**"
 
-		err := user.Register(username, password)
+		err := user.Register(ctx, username, password)
 		if err != nil {
 			t.Errorf("failed to register user with special characters: %v", err)
 		}
 	})
 
 	t.Run("this should hash the password", func(t *testing.T) {
-		user := NewAuthService().(*authService)
+		user := newTestAuthService().(*authService)
 
 		password := "meti1234"
 
-		err := user.Register("meti", password)
+		err := user.Register(ctx, "meti", password)
 
 		if err != nil {
 			t.Fatalf("registration faild %v", err)
 		}
 
-		user.mu.RLock()
-		storedHash := user.users["meti"]
-		user.mu.RUnlock()
+		storedUser, err := user.repo.GetByUsername(ctx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
 
-		if storedHash == password {
+		record, ok := findCredential(storedUser, CredentialKindPassword)
+		if !ok {
+			t.Fatalf("expected a password credential to be stored, got %+v", storedUser.Credentials)
+		}
+
+		if record.Fields["hash"] == password {
 			t.Error("the password is not hashed it is stored in plain text")
 		}
 
-		err = bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password))
+		err = bcrypt.CompareHashAndPassword([]byte(record.Fields["hash"]), []byte(password))
 
 		if err != nil {
 			t.Errorf("the stored hash is not valid %v", err)
@@ -108,7 +149,7 @@ func TestRegister(t *testing.T) {
 	})
 
 	t.Run("handling concurrent registration safely", func(t *testing.T) {
-		user := NewAuthService()
+		user := newTestAuthService()
 		var wg sync.WaitGroup
 		trail := 100
 
@@ -118,7 +159,7 @@ func TestRegister(t *testing.T) {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				err := user.Register("New_user", "newuser12")
+				err := user.Register(ctx, "New_user", "newuser12")
 				results <- err
 			}()
 		}
@@ -147,26 +188,31 @@ func TestRegister(t *testing.T) {
 }
 
 func TestLogin(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("should login users that are existing", func(t *testing.T) {
-		user := NewAuthService()
-		user.Register("meti", "meti1234")
-		token, err := user.Login("meti", "meti1234")
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, err := user.Login(ctx, "meti", "meti1234")
 
 		if err != nil {
 			t.Fatalf("expected no error but got %v", err)
 		}
 
-		expectedToken := "jwt_token_for_meti"
+		claims, err := user.VerifyToken(token)
+		if err != nil {
+			t.Fatalf("expected issued token to verify, got %v", err)
+		}
 
-		if token != expectedToken {
-			t.Errorf("expected token %s, but got %s", expectedToken, token)
+		if claims.Sub != "meti" {
+			t.Errorf("expected sub %q, got %q", "meti", claims.Sub)
 		}
 	})
 
 	t.Run("empty user name field should result error", func(t *testing.T) {
-		user := NewAuthService()
-		user.Register("meti", "meti1234")
-		token, err := user.Login("", "meti1234")
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, err := user.Login(ctx, "", "meti1234")
 
 		if err == nil {
 			t.Fatalf("expected %v error but got none", err)
@@ -182,9 +228,9 @@ func TestLogin(t *testing.T) {
 	})
 
 	t.Run("empty password field should result error", func(t *testing.T) {
-		user := NewAuthService()
-		user.Register("meti", "meti1234")
-		token, err := user.Login("meti", "")
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, err := user.Login(ctx, "meti", "")
 
 		if err == nil {
 			t.Fatalf("expected %v error but got none", err)
@@ -200,9 +246,9 @@ func TestLogin(t *testing.T) {
 	})
 
 	t.Run("should return error for non existing user", func(t *testing.T) {
-		user := NewAuthService()
-		user.Register("meti", "meti1234")
-		token, err := user.Login("metiTamir", "meti1234")
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, err := user.Login(ctx, "metiTamir", "meti1234")
 
 		if err == nil {
 			t.Fatalf("expected %v error but got none", err)
@@ -218,9 +264,9 @@ func TestLogin(t *testing.T) {
 	})
 
 	t.Run("should return error for wrong password", func(t *testing.T) {
-		user := NewAuthService()
-		user.Register("meti", "meti1234")
-		token, err := user.Login("meti", "metiTamir")
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, err := user.Login(ctx, "meti", "metiTamir")
 
 		if err == nil {
 			t.Fatalf("expected %v error but got none", err)
@@ -236,30 +282,35 @@ func TestLogin(t *testing.T) {
 	})
 
 	t.Run("should handle special characters in username and password", func(t *testing.T) {
-		user := NewAuthService()
+		user := newTestAuthService()
 
-		user.Register("meti@gmail.com", "@meti##**")
+		user.Register(ctx, "meti@gmail.com", "@meti##**")
 
 		username := "meti@gmail.com"
 		password := "@meti##**"
 
-		token, err := user.Login(username, password)
+		token, err := user.Login(ctx, username, password)
 
 		if err != nil {
 			t.Errorf("failed to find user account with special characters: %v", err)
 		}
 
-		expectedToken := "jwt_token_for_meti@gmail.com"
-
-		if token != expectedToken {
-			t.Errorf("expected %s but got %s", expectedToken, token)
+		claims, err := user.VerifyToken(token)
+		if err != nil {
+			t.Fatalf("expected issued token to verify, got %v", err)
 		}
 
+		if claims.Sub != username {
+			t.Errorf("expected sub %q but got %q", username, claims.Sub)
+		}
 	})
 
 	t.Run("handling concurrent login safely", func(t *testing.T) {
-		user := NewAuthService()
-		user.Register("meti", "meti1234")
+		// A generous limiter so this test exercises Login's own concurrency
+		// safety rather than the default rate limit (see TestLoginRateLimiting).
+		limiter := NewLoginLimiter(LimiterConfig{BucketSize: 1000})
+		user := newTestAuthService(WithLimiter(limiter))
+		user.Register(ctx, "meti", "meti1234")
 		var wg sync.WaitGroup
 		trail := 100
 
@@ -269,7 +320,7 @@ func TestLogin(t *testing.T) {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				_, err := user.Login("meti", "meti1234")
+				_, err := user.Login(ctx, "meti", "meti1234")
 				results <- err
 			}()
 		}
@@ -277,25 +328,146 @@ func TestLogin(t *testing.T) {
 		wg.Wait()
 		close(results)
 
-for err := range results {
-        if err != nil {
-            t.Errorf("expected all logins to succeed, got error: %v", err)
-        }
-    }
+		for err := range results {
+			if err != nil {
+				t.Errorf("expected all logins to succeed, got error: %v", err)
+			}
+		}
+	})
+}
+
+func TestVerifyToken(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should reject an expired token", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.TokenTTL = -time.Minute
+		user := NewAuthService(cfg)
+		user.Register(ctx, "meti", "meti1234")
+
+		token, err := user.Login(ctx, "meti", "meti1234")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		_, err = user.VerifyToken(token)
+		if err != ErrTokenExpired {
+			t.Errorf("expected ErrTokenExpired, got %v", err)
+		}
+	})
+
+	t.Run("should reject a token signed with the wrong key", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, _ := user.Login(ctx, "meti", "meti1234")
+
+		otherSigner := NewHMACSigner("test-key", []byte("a-different-secret"))
+		impostor := NewAuthService(AuthConfig{Keys: NewKeySet(otherSigner)})
+
+		if _, err := impostor.VerifyToken(token); err != ErrTokenSignature {
+			t.Errorf("expected ErrTokenSignature, got %v", err)
+		}
+	})
+
+	t.Run("should reject a token with tampered claims", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, _ := user.Login(ctx, "meti", "meti1234")
+
+		parts := strings.Split(token, ".")
+		tampered := parts[0] + "." + encodeSegment([]byte(`{"sub":"someone-else","exp":9999999999}`)) + "." + parts[2]
+
+		if _, err := user.VerifyToken(tampered); err != ErrTokenSignature {
+			t.Errorf("expected ErrTokenSignature, got %v", err)
+		}
+	})
+
+	t.Run("should verify tokens from a rotated-out key while the rotation is active", func(t *testing.T) {
+		cfg := testConfig()
+		user := NewAuthService(cfg)
+		user.Register(ctx, "meti", "meti1234")
+
+		oldToken, err := user.Login(ctx, "meti", "meti1234")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		cfg.Keys.Rotate(NewHMACSigner("test-key-2", []byte("another-secret")))
+
+		if _, err := user.VerifyToken(oldToken); err != nil {
+			t.Errorf("expected old token to still verify after rotation, got %v", err)
+		}
+
+		newToken, err := user.Login(ctx, "meti", "meti1234")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		claims, err := user.VerifyToken(newToken)
+		if err != nil {
+			t.Fatalf("expected new token to verify, got %v", err)
+		}
+		if claims.Sub != "meti" {
+			t.Errorf("expected sub %q, got %q", "meti", claims.Sub)
+		}
+	})
+
+	t.Run("should reject a token whose key id is unknown", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+		token, _ := user.Login(ctx, "meti", "meti1234")
+
+		parts := strings.Split(token, ".")
+		tamperedHeader := encodeSegment([]byte(`{"alg":"HS256","typ":"JWT","kid":"no-such-key"}`))
+		tampered := tamperedHeader + "." + parts[1] + "." + parts[2]
+
+		if _, err := user.VerifyToken(tampered); err != ErrUnknownKeyID {
+			t.Errorf("expected ErrUnknownKeyID, got %v", err)
+		}
+	})
+
+	t.Run("should reject a token whose issuer does not match", func(t *testing.T) {
+		cfg := testConfig()
+		user := NewAuthService(cfg)
+		user.Register(ctx, "meti", "meti1234")
+		token, _ := user.Login(ctx, "meti", "meti1234")
+
+		cfg.Issuer = "some-other-issuer"
+		verifier := NewAuthService(cfg)
+
+		if _, err := verifier.VerifyToken(token); err != ErrTokenIssuer {
+			t.Errorf("expected ErrTokenIssuer, got %v", err)
+		}
+	})
+
+	t.Run("should reject a token whose audience does not match", func(t *testing.T) {
+		cfg := testConfig()
+		user := NewAuthService(cfg)
+		user.Register(ctx, "meti", "meti1234")
+		token, _ := user.Login(ctx, "meti", "meti1234")
+
+		cfg.Audience = "some-other-audience"
+		verifier := NewAuthService(cfg)
+
+		if _, err := verifier.VerifyToken(token); err != ErrTokenAudience {
+			t.Errorf("expected ErrTokenAudience, got %v", err)
+		}
 	})
 }
 
 func BenchmarkRegister(b *testing.B) {
-    user := NewAuthService()
-    for i := 0; i < b.N; i++ {
-        user.Register(fmt.Sprintf("meti %d", i), "meti1234")
-    }
+	ctx := context.Background()
+	user := NewAuthService(testConfig(), WithBcryptCost(bcrypt.MinCost))
+	for i := 0; i < b.N; i++ {
+		user.Register(ctx, fmt.Sprintf("meti %d", i), "meti1234")
+	}
 }
 
 func BenchmarkLogin(b *testing.B) {
-    user := NewAuthService()
-    user.Register("meti", "meti1234")
-    for i := 0; i < b.N; i++ {
-        user.Login("meti", "meti1234")
-    }
-}
\ No newline at end of file
+	ctx := context.Background()
+	user := NewAuthService(testConfig(), WithBcryptCost(bcrypt.MinCost))
+	user.Register(ctx, "meti", "meti1234")
+	for i := 0; i < b.N; i++ {
+		user.Login(ctx, "meti", "meti1234")
+	}
+}
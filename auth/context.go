@@ -0,0 +1,21 @@
+package auth
+
+import "context"
+
+type contextKey int
+
+const ipContextKey contextKey = iota
+
+// ContextWithIP returns a copy of ctx carrying the caller's IP address, for
+// LoginLimiter to key on. HTTP-facing callers should set this from the
+// incoming request's remote address before calling Login or LoginWith.
+func ContextWithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ipContextKey, ip)
+}
+
+// IPFromContext returns the IP address set by ContextWithIP, or "" if none
+// was set.
+func IPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ipContextKey).(string)
+	return ip
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/MetiTamru/auth-with-test-golang-project/auth/store"
+)
+
+// CredentialKind identifies which concrete Credential a store.CredentialRecord
+// was built from.
+type CredentialKind string
+
+const (
+	CredentialKindPassword CredentialKind = "password"
+	CredentialKindToken    CredentialKind = "token"
+	CredentialKindOAuth    CredentialKind = "oauth"
+)
+
+// Credential is something a user can authenticate with. Every Credential
+// knows which account it belongs to, so LoginWith can dispatch to the right
+// user and verifier without a separate lookup.
+type Credential interface {
+	Kind() CredentialKind
+	Owner() string
+}
+
+// CredentialMeta describes a stored credential without exposing any secret
+// material, for ListCredentials.
+type CredentialMeta struct {
+	ID       string
+	Kind     CredentialKind
+	Provider string // set only for CredentialKindOAuth
+}
+
+// PasswordCredential authenticates with a username and password.
+type PasswordCredential struct {
+	Username string
+	Password string
+}
+
+func (c PasswordCredential) Kind() CredentialKind { return CredentialKindPassword }
+func (c PasswordCredential) Owner() string        { return c.Username }
+
+// TokenCredential authenticates with a long-lived API token. Token is the
+// raw secret; it is hashed (with a random salt) before it ever reaches a
+// UserRepository.
+type TokenCredential struct {
+	Username string
+	Token    string
+}
+
+func (c TokenCredential) Kind() CredentialKind { return CredentialKindToken }
+func (c TokenCredential) Owner() string        { return c.Username }
+
+// OAuthCredential authenticates as the external subject of an OAuth
+// provider, e.g. ("google", "109...487").
+type OAuthCredential struct {
+	Username   string
+	Provider   string
+	ExternalID string
+}
+
+func (c OAuthCredential) Kind() CredentialKind { return CredentialKindOAuth }
+func (c OAuthCredential) Owner() string        { return c.Username }
+
+var (
+	ErrCredentialKindMismatch = errors.New("no credential of this kind is registered for this user")
+	ErrUnsupportedCredential  = errors.New("unsupported credential kind")
+)
+
+func newCredentialID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func randomSalt() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// toRecord hashes/serializes c into a store.CredentialRecord.
+func (a *authService) toRecord(c Credential) (store.CredentialRecord, error) {
+	id, err := newCredentialID()
+	if err != nil {
+		return store.CredentialRecord{}, err
+	}
+
+	switch cred := c.(type) {
+	case PasswordCredential:
+		hash, err := a.hasher.Hash(cred.Password)
+		if err != nil {
+			return store.CredentialRecord{}, err
+		}
+		return store.CredentialRecord{
+			ID:     id,
+			Kind:   string(CredentialKindPassword),
+			Fields: map[string]string{"hash": hash},
+		}, nil
+
+	case TokenCredential:
+		salt, err := randomSalt()
+		if err != nil {
+			return store.CredentialRecord{}, err
+		}
+		hash, err := a.hasher.Hash(salt + cred.Token)
+		if err != nil {
+			return store.CredentialRecord{}, err
+		}
+		return store.CredentialRecord{
+			ID:     id,
+			Kind:   string(CredentialKindToken),
+			Fields: map[string]string{"salt": salt, "hash": hash},
+		}, nil
+
+	case OAuthCredential:
+		return store.CredentialRecord{
+			ID:   id,
+			Kind: string(CredentialKindOAuth),
+			Fields: map[string]string{
+				"provider":   cred.Provider,
+				"externalId": cred.ExternalID,
+			},
+		}, nil
+
+	default:
+		return store.CredentialRecord{}, ErrUnsupportedCredential
+	}
+}
+
+// verify checks a login attempt's credential against its matching stored
+// record.
+func (a *authService) verify(c Credential, record store.CredentialRecord) error {
+	switch cred := c.(type) {
+	case PasswordCredential:
+		return a.hasher.Compare(record.Fields["hash"], cred.Password)
+
+	case TokenCredential:
+		return a.hasher.Compare(record.Fields["hash"], record.Fields["salt"]+cred.Token)
+
+	case OAuthCredential:
+		if record.Fields["provider"] != cred.Provider || record.Fields["externalId"] != cred.ExternalID {
+			return errors.New("oauth credential does not match")
+		}
+		return nil
+
+	default:
+		return ErrUnsupportedCredential
+	}
+}
+
+func findCredential(user store.User, kind CredentialKind) (store.CredentialRecord, bool) {
+	records := findCredentials(user, kind)
+	if len(records) == 0 {
+		return store.CredentialRecord{}, false
+	}
+	return records[0], true
+}
+
+// findCredentials returns every stored record of kind for user. A kind can
+// have more than one record at a time, e.g. while an API token is being
+// rotated (add the new token, verify it works, then remove the old one).
+func findCredentials(user store.User, kind CredentialKind) []store.CredentialRecord {
+	var records []store.CredentialRecord
+	for _, record := range user.Credentials {
+		if record.Kind == string(kind) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// verifyAny reports whether c matches any of records, trying them in order
+// until one succeeds.
+func (a *authService) verifyAny(c Credential, records []store.CredentialRecord) bool {
+	for _, record := range records {
+		if a.verify(c, record) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func toCredentialMeta(record store.CredentialRecord) CredentialMeta {
+	return CredentialMeta{
+		ID:       record.ID,
+		Kind:     CredentialKind(record.Kind),
+		Provider: record.Fields["provider"],
+	}
+}
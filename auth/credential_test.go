@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestMultiCredentialLogin(t *testing.T) {
+	t.Run("a user can log in with a password and an API token", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(testCtx, "meti", "meti1234")
+
+		if err := user.AddCredential(testCtx, "meti", TokenCredential{Username: "meti", Token: "api-token-123"}); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if _, err := user.Login(testCtx, "meti", "meti1234"); err != nil {
+			t.Errorf("expected password login to still work, got %v", err)
+		}
+
+		if _, err := user.LoginWith(testCtx, TokenCredential{Username: "meti", Token: "api-token-123"}); err != nil {
+			t.Errorf("expected token login to work, got %v", err)
+		}
+
+		if _, err := user.LoginWith(testCtx, TokenCredential{Username: "meti", Token: "wrong-token"}); err == nil {
+			t.Error("expected a wrong token to fail login")
+		}
+	})
+
+	t.Run("LoginWith an OAuth credential", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(testCtx, "meti", "meti1234")
+
+		cred := OAuthCredential{Username: "meti", Provider: "google", ExternalID: "ext-123"}
+		if err := user.AddCredential(testCtx, "meti", cred); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if _, err := user.LoginWith(testCtx, cred); err != nil {
+			t.Errorf("expected oauth login to work, got %v", err)
+		}
+
+		wrong := OAuthCredential{Username: "meti", Provider: "google", ExternalID: "someone-else"}
+		if _, err := user.LoginWith(testCtx, wrong); err == nil {
+			t.Error("expected a mismatched external id to fail login")
+		}
+	})
+
+	t.Run("LoginWith fails for a credential kind the user never registered", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(testCtx, "meti", "meti1234")
+
+		_, err := user.LoginWith(testCtx, TokenCredential{Username: "meti", Token: "never-added"})
+		if err != ErrCredentialKindMismatch {
+			t.Errorf("expected ErrCredentialKindMismatch, got %v", err)
+		}
+	})
+
+	t.Run("ListCredentials reports every credential without secrets", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(testCtx, "meti", "meti1234")
+		user.AddCredential(testCtx, "meti", TokenCredential{Username: "meti", Token: "api-token-123"})
+
+		metas, err := user.ListCredentials(testCtx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if len(metas) != 2 {
+			t.Fatalf("expected 2 credentials, got %d", len(metas))
+		}
+
+		kinds := map[CredentialKind]bool{}
+		for _, meta := range metas {
+			kinds[meta.Kind] = true
+		}
+		if !kinds[CredentialKindPassword] || !kinds[CredentialKindToken] {
+			t.Errorf("expected password and token kinds, got %+v", metas)
+		}
+	})
+
+	t.Run("rotating an API token revokes the old one and lets the new one log in", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(testCtx, "meti", "meti1234")
+		user.AddCredential(testCtx, "meti", TokenCredential{Username: "meti", Token: "old-token"})
+
+		metas, err := user.ListCredentials(testCtx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		var tokenCredID string
+		for _, meta := range metas {
+			if meta.Kind == CredentialKindToken {
+				tokenCredID = meta.ID
+			}
+		}
+		if tokenCredID == "" {
+			t.Fatal("expected to find the token credential id")
+		}
+
+		if err := user.RemoveCredential(testCtx, "meti", tokenCredID); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if err := user.AddCredential(testCtx, "meti", TokenCredential{Username: "meti", Token: "new-token"}); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if _, err := user.LoginWith(testCtx, TokenCredential{Username: "meti", Token: "old-token"}); err == nil {
+			t.Error("expected the rotated-out token to fail login")
+		}
+		if _, err := user.LoginWith(testCtx, TokenCredential{Username: "meti", Token: "new-token"}); err != nil {
+			t.Errorf("expected the new token to log in, got %v", err)
+		}
+	})
+
+	t.Run("a new token logs in while the old one it is replacing still exists", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(testCtx, "meti", "meti1234")
+		user.AddCredential(testCtx, "meti", TokenCredential{Username: "meti", Token: "old-token"})
+
+		if err := user.AddCredential(testCtx, "meti", TokenCredential{Username: "meti", Token: "new-token"}); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if _, err := user.LoginWith(testCtx, TokenCredential{Username: "meti", Token: "new-token"}); err != nil {
+			t.Errorf("expected the new token to log in even though the old one hasn't been removed yet, got %v", err)
+		}
+		if _, err := user.LoginWith(testCtx, TokenCredential{Username: "meti", Token: "old-token"}); err != nil {
+			t.Errorf("expected the old token to still log in until it is explicitly removed, got %v", err)
+		}
+	})
+
+	t.Run("RemoveCredential errors for an unknown credential id", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(testCtx, "meti", "meti1234")
+
+		err := user.RemoveCredential(testCtx, "meti", "no-such-credential")
+		if err == nil {
+			t.Error("expected an error for an unknown credential id")
+		}
+	})
+}
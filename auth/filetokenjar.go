@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileTokenJar wraps a MemoryTokenJar and periodically flushes it to disk,
+// rather than syncing on every mutation, so a burst of logins or revocations
+// doesn't turn into a burst of disk writes. On construction it loads
+// whatever was last flushed and drops anything already expired.
+type FileTokenJar struct {
+	*MemoryTokenJar
+
+	path          string
+	writeInterval time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// defaultWriteInterval matches the request's "every 30s" example cadence.
+const defaultWriteInterval = 30 * time.Second
+
+// NewFileTokenJar loads path (if it exists), purges expired records, and
+// starts a background goroutine that flushes to path every writeInterval.
+// A writeInterval <= 0 defaults to 30s. Call Close to stop the goroutine and
+// flush one last time.
+func NewFileTokenJar(path string, writeInterval time.Duration) (*FileTokenJar, error) {
+	if writeInterval <= 0 {
+		writeInterval = defaultWriteInterval
+	}
+
+	jar := &FileTokenJar{
+		MemoryTokenJar: NewMemoryTokenJar(),
+		path:           path,
+		writeInterval:  writeInterval,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	if err := jar.load(); err != nil {
+		return nil, err
+	}
+	jar.purgeExpired(time.Now())
+
+	go jar.flushLoop()
+
+	return jar, nil
+}
+
+func (j *FileTokenJar) load() error {
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var records []TokenRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		j.MemoryTokenJar.records[record.ID] = record
+	}
+	return nil
+}
+
+func (j *FileTokenJar) flushLoop() {
+	defer close(j.done)
+	ticker := time.NewTicker(j.writeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.flush()
+		case <-j.stop:
+			j.flush()
+			return
+		}
+	}
+}
+
+func (j *FileTokenJar) flush() error {
+	records := j.snapshot()
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(j.path, data, 0o600)
+}
+
+// Close stops the flush goroutine after one last write to path.
+func (j *FileTokenJar) Close() error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	<-j.done
+	return nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTokenJar(t *testing.T) {
+	t.Run("persists records across reopen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens.json")
+
+		jar, err := NewFileTokenJar(path, time.Hour)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		record := TokenRecord{ID: "tok-1", Subject: "meti", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := jar.Put(record); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if err := jar.Revoke("tok-1"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if err := jar.Close(); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		reopened, err := NewFileTokenJar(path, time.Hour)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		defer reopened.Close()
+
+		got, ok, err := reopened.Get("tok-1")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if !ok || !got.Revoked {
+			t.Errorf("expected tok-1 to reload as revoked, got %+v ok=%v", got, ok)
+		}
+	})
+
+	t.Run("purges already-expired records on load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens.json")
+
+		jar, err := NewFileTokenJar(path, time.Hour)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		jar.Put(TokenRecord{ID: "long-gone", ExpiresAt: time.Now().Add(-time.Hour)})
+		jar.Close()
+
+		reopened, err := NewFileTokenJar(path, time.Hour)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		defer reopened.Close()
+
+		if _, ok, _ := reopened.Get("long-gone"); ok {
+			t.Error("expected the expired record to be purged on load")
+		}
+	})
+
+	t.Run("loading a missing file starts with an empty jar", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "does-not-exist.json")
+
+		jar, err := NewFileTokenJar(path, time.Hour)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		defer jar.Close()
+
+		if _, ok, _ := jar.Get("anything"); ok {
+			t.Error("expected an empty jar for a missing file")
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher decouples password hashing from authService so production
+// code can use bcrypt while tests swap in something fast.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
+}
+
+// BcryptHasher is the default PasswordHasher, used unless an Option
+// overrides it.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher builds a BcryptHasher using bcrypt.DefaultCost.
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{cost: bcrypt.DefaultCost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Compare(hash, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return errors.New("invalid password")
+	}
+	return nil
+}
+
+// PlainHasher stores passwords as-is. It exists for tests that want to skip
+// bcrypt's cost entirely; never use it in production.
+type PlainHasher struct{}
+
+func (PlainHasher) Hash(password string) (string, error) {
+	return password, nil
+}
+
+func (PlainHasher) Compare(hash, password string) error {
+	if hash != password {
+		return errors.New("invalid password")
+	}
+	return nil
+}
+
+// MockHasher records every Hash/Compare call it receives, for tests that
+// need to assert on hasher usage rather than just swap in a fast one.
+type MockHasher struct {
+	HashCalls    []string
+	CompareCalls []string
+}
+
+func (m *MockHasher) Hash(password string) (string, error) {
+	m.HashCalls = append(m.HashCalls, password)
+	return "mock:" + password, nil
+}
+
+func (m *MockHasher) Compare(hash, password string) error {
+	m.CompareCalls = append(m.CompareCalls, password)
+	if hash != "mock:"+password {
+		return errors.New("invalid password")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcryptHasher(t *testing.T) {
+	h := NewBcryptHasher()
+
+	hash, err := h.Hash("meti1234")
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if hash == "meti1234" {
+		t.Error("expected the hash to differ from the plain password")
+	}
+
+	if err := h.Compare(hash, "meti1234"); err != nil {
+		t.Errorf("expected matching password to compare cleanly, got %v", err)
+	}
+
+	if err := h.Compare(hash, "wrong-password"); err == nil {
+		t.Error("expected mismatched password to return an error")
+	}
+}
+
+func TestPlainHasher(t *testing.T) {
+	h := PlainHasher{}
+
+	hash, err := h.Hash("meti1234")
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if hash != "meti1234" {
+		t.Errorf("expected plain hasher to store the password as-is, got %q", hash)
+	}
+
+	if err := h.Compare(hash, "meti1234"); err != nil {
+		t.Errorf("expected matching password to compare cleanly, got %v", err)
+	}
+
+	if err := h.Compare(hash, "wrong-password"); err == nil {
+		t.Error("expected mismatched password to return an error")
+	}
+}
+
+func TestWithHasherOption(t *testing.T) {
+	ctx := context.Background()
+	mock := &MockHasher{}
+	user := newTestAuthService(WithHasher(mock)).(*authService)
+
+	if err := user.Register(ctx, "meti", "meti1234"); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if len(mock.HashCalls) != 1 || mock.HashCalls[0] != "meti1234" {
+		t.Errorf("expected the configured hasher to be used for Hash, got %+v", mock.HashCalls)
+	}
+
+	if _, err := user.Login(ctx, "meti", "meti1234"); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if len(mock.CompareCalls) != 1 || mock.CompareCalls[0] != "meti1234" {
+		t.Errorf("expected the configured hasher to be used for Compare, got %+v", mock.CompareCalls)
+	}
+}
+
+func TestWithBcryptCostOption(t *testing.T) {
+	ctx := context.Background()
+	user := newTestAuthService().(*authService)
+
+	if err := user.Register(ctx, "meti", "meti1234"); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	storedUser, err := user.repo.GetByUsername(ctx, "meti")
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	record, ok := findCredential(storedUser, CredentialKindPassword)
+	if !ok {
+		t.Fatalf("expected a password credential to be stored, got %+v", storedUser.Credentials)
+	}
+
+	cost, err := bcrypt.Cost([]byte(record.Fields["hash"]))
+	if err != nil {
+		t.Fatalf("expected a valid bcrypt hash, got error %v", err)
+	}
+
+	if cost != bcrypt.MinCost {
+		t.Errorf("expected cost %d, got %d", bcrypt.MinCost, cost)
+	}
+}
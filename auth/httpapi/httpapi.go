@@ -0,0 +1,284 @@
+// Package httpapi wraps an auth.AuthService as net/http handlers, so a
+// service can mount register/login/logout/me endpoints directly instead of
+// hand-rolling the HTTP plumbing around the library.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MetiTamru/auth-with-test-golang-project/auth"
+)
+
+// Server adapts an auth.AuthService to net/http handlers.
+type Server struct {
+	service auth.AuthService
+	cookie  cookieConfig
+}
+
+type cookieConfig struct {
+	enabled bool
+	name    string
+	secure  bool
+}
+
+// Option configures a Server at construction time.
+type Option func(*Server)
+
+// WithCookie enables CSRF-safe cookie mode: a successful login also sets an
+// HttpOnly, SameSite=Strict cookie named name carrying the issued token, in
+// addition to returning it in the JSON response body. secure sets the
+// cookie's Secure attribute and should be true for any service served over
+// HTTPS.
+func WithCookie(name string, secure bool) Option {
+	return func(s *Server) {
+		s.cookie = cookieConfig{enabled: true, name: name, secure: secure}
+	}
+}
+
+// NewServer builds an httpapi.Server that exposes service's register, login,
+// logout, and me endpoints over HTTP. By default a successful login returns
+// the token only in the JSON response body; pass WithCookie to also set a
+// session cookie.
+func NewServer(service auth.AuthService, opts ...Option) *Server {
+	server := &Server{service: service, cookie: cookieConfig{name: "auth_token"}}
+
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	return server
+}
+
+// Mount registers the Server's handlers on mux under prefix: POST
+// prefix+"/register", POST prefix+"/login", POST prefix+"/logout", and GET
+// prefix+"/me" behind AuthMiddleware.
+func (s *Server) Mount(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc(prefix+"/register", s.handleRegister)
+	mux.HandleFunc(prefix+"/login", s.handleLogin)
+	mux.HandleFunc(prefix+"/logout", s.handleLogout)
+	mux.Handle(prefix+"/me", s.AuthMiddleware(http.HandlerFunc(s.handleMe)))
+}
+
+type registerRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req registerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+
+	if err := s.service.Register(r.Context(), req.Username, req.Password); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token string `json:"token"`
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+
+	ctx := auth.ContextWithIP(r.Context(), clientIP(r))
+	token, err := s.service.Login(ctx, req.Username, req.Password)
+	if err != nil {
+		var rlErr *auth.RateLimitedError
+		if errors.As(err, &rlErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(rlErr)))
+			writeError(w, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if s.cookie.enabled {
+		http.SetCookie(w, &http.Cookie{
+			Name:     s.cookie.name,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   s.cookie.secure,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, loginResponse{Token: token})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := s.tokenFromRequest(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "missing bearer token")
+		return
+	}
+
+	claims, err := s.service.VerifyToken(token)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	if err := s.service.Revoke(claims.Jti); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if s.cookie.enabled {
+		http.SetCookie(w, &http.Cookie{Name: s.cookie.name, Value: "", Path: "/", MaxAge: -1})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "missing claims")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, claims)
+}
+
+type claimsContextKey int
+
+const claimsKey claimsContextKey = iota
+
+// AuthMiddleware parses an "Authorization: Bearer <token>" header (or, when
+// cookie mode is enabled, falls back to the session cookie), verifies the
+// token against the wrapped AuthService, and injects the resulting claims
+// into the request context for downstream handlers to read with
+// ClaimsFromContext. Requests with a missing, malformed, or invalid token
+// are rejected with 401 before reaching next.
+func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := s.tokenFromRequest(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		claims, err := s.service.VerifyToken(token)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), claimsKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClaimsFromContext returns the auth.Claims injected by AuthMiddleware, if
+// any.
+func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*auth.Claims)
+	return claims, ok
+}
+
+// tokenFromRequest looks for the token in the Authorization header first,
+// falling back to the session cookie when cookie mode is enabled. This lets
+// a cookie-mode client call /me and /logout using the HttpOnly cookie alone,
+// without ever having to read the token into JS.
+func (s *Server) tokenFromRequest(r *http.Request) (string, bool) {
+	if token, ok := bearerToken(r); ok {
+		return token, true
+	}
+
+	if !s.cookie.enabled {
+		return "", false
+	}
+
+	cookie, err := r.Cookie(s.cookie.name)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+
+	return cookie.Value, true
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		return "", false
+	}
+
+	token := strings.TrimPrefix(header, prefix)
+	if token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+// clientIP extracts the request's remote IP, stripping the port net/http
+// leaves on RemoteAddr.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func retryAfterSeconds(err *auth.RateLimitedError) int {
+	secs := int(err.RetryAfter.Seconds())
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
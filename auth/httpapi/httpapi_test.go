@@ -0,0 +1,245 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/MetiTamru/auth-with-test-golang-project/auth"
+)
+
+func testConfig() auth.AuthConfig {
+	return auth.AuthConfig{
+		Keys:     auth.NewKeySet(auth.NewHMACSigner("test-key", []byte("test-secret"))),
+		Issuer:   "httpapi-test",
+		Audience: "httpapi-test-clients",
+		TokenTTL: time.Hour,
+	}
+}
+
+func newTestServer(opts ...Option) (*Server, *http.ServeMux) {
+	service := auth.NewAuthService(testConfig(),
+		auth.WithBcryptCost(bcrypt.MinCost),
+		auth.WithLimiter(auth.NewLoginLimiter(auth.LimiterConfig{BucketSize: 1000})),
+	)
+	server := NewServer(service, opts...)
+	mux := http.NewServeMux()
+	server.Mount(mux, "/auth")
+	return server, mux
+}
+
+func doJSON(t *testing.T, mux *http.ServeMux, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to marshal request body: %v", err)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeLogin(t *testing.T, rec *httptest.ResponseRecorder) loginResponse {
+	t.Helper()
+
+	var resp loginResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode login response: %v", err)
+	}
+	return resp
+}
+
+func TestRegisterLoginMe(t *testing.T) {
+	_, mux := newTestServer()
+
+	rec := doJSON(t, mux, http.MethodPost, "/auth/register", registerRequest{Username: "meti", Password: "meti1234"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doJSON(t, mux, http.MethodPost, "/auth/login", loginRequest{Username: "meti", Password: "meti1234"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	resp := decodeLogin(t, rec)
+	if resp.Token == "" {
+		t.Fatal("expected a non-empty token")
+	}
+
+	meReq := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	meReq.Header.Set("Authorization", "Bearer "+resp.Token)
+	meRec := httptest.NewRecorder()
+	mux.ServeHTTP(meRec, meReq)
+
+	if meRec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", meRec.Code, meRec.Body.String())
+	}
+
+	var claims auth.Claims
+	if err := json.NewDecoder(meRec.Body).Decode(&claims); err != nil {
+		t.Fatalf("failed to decode claims: %v", err)
+	}
+	if claims.Sub != "meti" {
+		t.Errorf("expected sub %q, got %q", "meti", claims.Sub)
+	}
+}
+
+func TestRegisterRejectsMalformedJSON(t *testing.T) {
+	_, mux := newTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader("{not json"))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestLoginRejectsMalformedJSON(t *testing.T) {
+	_, mux := newTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader("not even json"))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestMeRejectsMissingToken(t *testing.T) {
+	_, mux := newTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestMeRejectsExpiredToken(t *testing.T) {
+	cfg := testConfig()
+	cfg.TokenTTL = -time.Minute
+	service := auth.NewAuthService(cfg, auth.WithBcryptCost(bcrypt.MinCost))
+	server := NewServer(service)
+	mux := http.NewServeMux()
+	server.Mount(mux, "/auth")
+
+	doJSON(t, mux, http.MethodPost, "/auth/register", registerRequest{Username: "meti", Password: "meti1234"})
+	rec := doJSON(t, mux, http.MethodPost, "/auth/login", loginRequest{Username: "meti", Password: "meti1234"})
+	resp := decodeLogin(t, rec)
+
+	meReq := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	meReq.Header.Set("Authorization", "Bearer "+resp.Token)
+	meRec := httptest.NewRecorder()
+	mux.ServeHTTP(meRec, meReq)
+
+	if meRec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an expired token, got %d", meRec.Code)
+	}
+}
+
+func TestLogoutRevokesToken(t *testing.T) {
+	_, mux := newTestServer()
+
+	doJSON(t, mux, http.MethodPost, "/auth/register", registerRequest{Username: "meti", Password: "meti1234"})
+	rec := doJSON(t, mux, http.MethodPost, "/auth/login", loginRequest{Username: "meti", Password: "meti1234"})
+	resp := decodeLogin(t, rec)
+
+	logoutReq := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	logoutReq.Header.Set("Authorization", "Bearer "+resp.Token)
+	logoutRec := httptest.NewRecorder()
+	mux.ServeHTTP(logoutRec, logoutReq)
+
+	if logoutRec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", logoutRec.Code, logoutRec.Body.String())
+	}
+
+	meReq := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	meReq.Header.Set("Authorization", "Bearer "+resp.Token)
+	meRec := httptest.NewRecorder()
+	mux.ServeHTTP(meRec, meReq)
+
+	if meRec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a revoked token, got %d", meRec.Code)
+	}
+}
+
+func TestCookieMode(t *testing.T) {
+	_, mux := newTestServer(WithCookie("auth_token", false))
+
+	doJSON(t, mux, http.MethodPost, "/auth/register", registerRequest{Username: "meti", Password: "meti1234"})
+	rec := doJSON(t, mux, http.MethodPost, "/auth/login", loginRequest{Username: "meti", Password: "meti1234"})
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected exactly one cookie, got %d", len(cookies))
+	}
+
+	cookie := cookies[0]
+	if cookie.Name != "auth_token" {
+		t.Errorf("expected cookie name %q, got %q", "auth_token", cookie.Name)
+	}
+	if !cookie.HttpOnly {
+		t.Error("expected the cookie to be HttpOnly")
+	}
+	if cookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("expected SameSite=Strict, got %v", cookie.SameSite)
+	}
+
+	resp := decodeLogin(t, rec)
+	if cookie.Value != resp.Token {
+		t.Error("expected the cookie value to match the token in the response body")
+	}
+
+	meReq := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	meReq.AddCookie(cookie)
+	meRec := httptest.NewRecorder()
+	mux.ServeHTTP(meRec, meReq)
+
+	if meRec.Code != http.StatusOK {
+		t.Fatalf("expected /me to authenticate from the cookie alone, got %d: %s", meRec.Code, meRec.Body.String())
+	}
+
+	logoutReq := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	logoutReq.AddCookie(cookie)
+	logoutRec := httptest.NewRecorder()
+	mux.ServeHTTP(logoutRec, logoutReq)
+
+	if logoutRec.Code != http.StatusNoContent {
+		t.Fatalf("expected /logout to authenticate from the cookie alone, got %d: %s", logoutRec.Code, logoutRec.Body.String())
+	}
+
+	meReq2 := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	meReq2.AddCookie(cookie)
+	meRec2 := httptest.NewRecorder()
+	mux.ServeHTTP(meRec2, meReq2)
+
+	if meRec2.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the revoked cookie token to be rejected, got %d", meRec2.Code)
+	}
+}
+
+func TestLoginWithoutCookieModeSetsNoCookie(t *testing.T) {
+	_, mux := newTestServer()
+
+	doJSON(t, mux, http.MethodPost, "/auth/register", registerRequest{Username: "meti", Password: "meti1234"})
+	rec := doJSON(t, mux, http.MethodPost, "/auth/login", loginRequest{Username: "meti", Password: "meti1234"})
+
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no cookie when cookie mode is not enabled")
+	}
+}
@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// SigningMethod identifies the JWT algorithm used to sign and verify tokens.
+type SigningMethod string
+
+const (
+	HS256 SigningMethod = "HS256"
+	RS256 SigningMethod = "RS256"
+	ES256 SigningMethod = "ES256"
+)
+
+var (
+	ErrTokenExpired   = errors.New("token is expired")
+	ErrTokenMalformed = errors.New("token is malformed")
+	ErrTokenSignature = errors.New("token signature is invalid")
+	ErrUnknownKeyID   = errors.New("token key id is not recognized")
+	ErrTokenIssuer    = errors.New("token issuer does not match")
+	ErrTokenAudience  = errors.New("token audience does not match")
+)
+
+// Claims are the registered JWT claims this package issues and verifies.
+type Claims struct {
+	Sub string `json:"sub"`
+	Iat int64  `json:"iat"`
+	Exp int64  `json:"exp"`
+	Iss string `json:"iss,omitempty"`
+	Aud string `json:"aud,omitempty"`
+	Jti string `json:"jti,omitempty"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// Signer signs and verifies the signature portion of a JWT for a single key.
+// Each Signer is identified by a KeyID so multiple keys can be active at once,
+// which is what makes key rotation possible: old tokens keep verifying against
+// their original key while new tokens are issued with the current one.
+type Signer interface {
+	Method() SigningMethod
+	KeyID() string
+	Sign(signingInput []byte) ([]byte, error)
+	Verify(signingInput, signature []byte) error
+}
+
+// HMACSigner implements Signer for HS256 using a shared secret.
+type HMACSigner struct {
+	kid    string
+	secret []byte
+}
+
+// NewHMACSigner builds an HS256 signer. kid identifies this key for rotation.
+func NewHMACSigner(kid string, secret []byte) *HMACSigner {
+	return &HMACSigner{kid: kid, secret: secret}
+}
+
+func (s *HMACSigner) Method() SigningMethod { return HS256 }
+func (s *HMACSigner) KeyID() string         { return s.kid }
+
+func (s *HMACSigner) Sign(signingInput []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(signingInput)
+	return mac.Sum(nil), nil
+}
+
+func (s *HMACSigner) Verify(signingInput, signature []byte) error {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(signingInput)
+	expected := mac.Sum(nil)
+	if subtle.ConstantTimeCompare(expected, signature) != 1 {
+		return ErrTokenSignature
+	}
+	return nil
+}
+
+// RSASigner implements Signer for RS256.
+type RSASigner struct {
+	kid        string
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+}
+
+// NewRSASigner builds an RS256 signer. privateKey may be nil for a
+// verify-only signer, in which case Sign returns an error.
+func NewRSASigner(kid string, privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) *RSASigner {
+	if publicKey == nil && privateKey != nil {
+		publicKey = &privateKey.PublicKey
+	}
+	return &RSASigner{kid: kid, privateKey: privateKey, publicKey: publicKey}
+}
+
+func (s *RSASigner) Method() SigningMethod { return RS256 }
+func (s *RSASigner) KeyID() string         { return s.kid }
+
+func (s *RSASigner) Sign(signingInput []byte) ([]byte, error) {
+	if s.privateKey == nil {
+		return nil, fmt.Errorf("jwt: signer %q has no private key", s.kid)
+	}
+	hashed := sha256.Sum256(signingInput)
+	return rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, hashed[:])
+}
+
+func (s *RSASigner) Verify(signingInput, signature []byte) error {
+	if s.publicKey == nil {
+		return fmt.Errorf("jwt: signer %q has no public key", s.kid)
+	}
+	hashed := sha256.Sum256(signingInput)
+	if err := rsa.VerifyPKCS1v15(s.publicKey, crypto.SHA256, hashed[:], signature); err != nil {
+		return ErrTokenSignature
+	}
+	return nil
+}
+
+// ECSigner implements Signer for ES256.
+type ECSigner struct {
+	kid        string
+	privateKey *ecdsa.PrivateKey
+	publicKey  *ecdsa.PublicKey
+}
+
+// NewECSigner builds an ES256 signer. privateKey may be nil for a
+// verify-only signer, in which case Sign returns an error.
+func NewECSigner(kid string, privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey) *ECSigner {
+	if publicKey == nil && privateKey != nil {
+		publicKey = &privateKey.PublicKey
+	}
+	return &ECSigner{kid: kid, privateKey: privateKey, publicKey: publicKey}
+}
+
+func (s *ECSigner) Method() SigningMethod { return ES256 }
+func (s *ECSigner) KeyID() string         { return s.kid }
+
+func (s *ECSigner) Sign(signingInput []byte) ([]byte, error) {
+	if s.privateKey == nil {
+		return nil, fmt.Errorf("jwt: signer %q has no private key", s.kid)
+	}
+	hashed := sha256.Sum256(signingInput)
+	r, sVal, err := ecdsa.Sign(rand.Reader, s.privateKey, hashed[:])
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, sVal})
+}
+
+func (s *ECSigner) Verify(signingInput, signature []byte) error {
+	if s.publicKey == nil {
+		return fmt.Errorf("jwt: signer %q has no public key", s.kid)
+	}
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+		return ErrTokenSignature
+	}
+	hashed := sha256.Sum256(signingInput)
+	if !ecdsa.Verify(s.publicKey, hashed[:], sig.R, sig.S) {
+		return ErrTokenSignature
+	}
+	return nil
+}
+
+// KeySet holds every signer an AuthService knows about, keyed by kid, plus
+// the one currently used to issue new tokens. Rotation works by adding a new
+// signer, pointing Active at it, and leaving the old signer in Verifiers so
+// outstanding tokens keep validating until they expire.
+type KeySet struct {
+	Active    Signer
+	Verifiers map[string]Signer
+}
+
+// NewKeySet builds a KeySet whose only key is also the active signing key.
+func NewKeySet(active Signer) *KeySet {
+	return &KeySet{
+		Active:    active,
+		Verifiers: map[string]Signer{active.KeyID(): active},
+	}
+}
+
+// AddKey registers an additional verification key without changing which
+// key is used to sign new tokens.
+func (k *KeySet) AddKey(s Signer) {
+	k.Verifiers[s.KeyID()] = s
+}
+
+// Rotate adds s as a verification key and makes it the active signing key.
+func (k *KeySet) Rotate(s Signer) {
+	k.Verifiers[s.KeyID()] = s
+	k.Active = s
+}
+
+func (k *KeySet) signerForKeyID(kid string) (Signer, error) {
+	s, ok := k.Verifiers[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return s, nil
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	return b, nil
+}
+
+// issueToken signs claims with keys.Active and returns the compact JWT.
+func issueToken(keys *KeySet, claims Claims) (string, error) {
+	header := jwtHeader{Alg: string(keys.Active.Method()), Typ: "JWT", Kid: keys.Active.KeyID()}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(headerJSON) + "." + encodeSegment(claimsJSON)
+	signature, err := keys.Active.Sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// parseAndVerifyToken validates the signature and expiry of token against
+// keys and returns the decoded claims. If issuer or audience is non-empty,
+// the corresponding claim on token must match it exactly.
+func parseAndVerifyToken(keys *KeySet, token string, now time.Time, issuer, audience string) (*Claims, error) {
+	parts := splitToken(token)
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	signer, err := keys.signerForKeyID(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if string(signer.Method()) != header.Alg {
+		return nil, ErrTokenSignature
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if err := signer.Verify([]byte(signingInput), signature); err != nil {
+		return nil, ErrTokenSignature
+	}
+
+	claimsJSON, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	if now.Unix() >= claims.Exp {
+		return &claims, ErrTokenExpired
+	}
+
+	if issuer != "" && claims.Iss != issuer {
+		return &claims, ErrTokenIssuer
+	}
+	if audience != "" && claims.Aud != audience {
+		return &claims, ErrTokenAudience
+	}
+
+	return &claims, nil
+}
+
+func splitToken(token string) []string {
+	parts := make([]string, 0, 3)
+	start := 0
+	for i := 0; i < len(token); i++ {
+		if token[i] == '.' {
+			parts = append(parts, token[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, token[start:])
+	return parts
+}
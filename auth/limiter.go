@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitedError is returned by Login/LoginWith when a LoginLimiter has
+// rejected the attempt, before bcrypt ever runs. RetryAfter says how long
+// the caller should wait before trying again. Use errors.Is(err,
+// ErrRateLimited) to detect it and errors.As to recover RetryAfter.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("too many login attempts, retry after %s", e.RetryAfter)
+}
+
+// Is makes errors.Is(err, ErrRateLimited) match any *RateLimitedError,
+// regardless of its RetryAfter value.
+func (e *RateLimitedError) Is(target error) bool {
+	_, ok := target.(*RateLimitedError)
+	return ok
+}
+
+// ErrRateLimited is the sentinel RateLimitedError used with errors.Is.
+var ErrRateLimited = &RateLimitedError{}
+
+// LoginLimiter throttles login attempts per (username, ip) pair to blunt
+// brute-force and credential-stuffing attacks. Login consults it before
+// touching bcrypt, so a locked-out caller never pays bcrypt's CPU cost.
+type LoginLimiter interface {
+	// Allow reports whether a login attempt for (username, ip) may proceed
+	// right now. If not, retryAfter says how long to wait before the next
+	// attempt might succeed.
+	Allow(username, ip string) (allowed bool, retryAfter time.Duration)
+	// RecordFailure registers a failed login attempt, counting toward
+	// lockout.
+	RecordFailure(username, ip string)
+	// RecordSuccess clears any failure count and lockout built up for
+	// (username, ip).
+	RecordSuccess(username, ip string)
+}
+
+// LimiterConfig configures the default LoginLimiter.
+type LimiterConfig struct {
+	// BucketSize is the number of login attempts a (username, ip) pair may
+	// make before it has to wait for the bucket to refill. Defaults to 10.
+	BucketSize int
+	// RefillInterval is how often one token is added back to the bucket.
+	// Defaults to 1 second.
+	RefillInterval time.Duration
+	// FailureThreshold is the number of consecutive failed attempts after
+	// which lockout kicks in, on top of the token bucket. Defaults to 5.
+	FailureThreshold int
+	// BaseDelay is the lockout duration applied as soon as FailureThreshold
+	// is reached. Defaults to 1 second.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff applied to further failures
+	// past FailureThreshold. Defaults to 1 minute.
+	MaxDelay time.Duration
+}
+
+func (c LimiterConfig) withDefaults() LimiterConfig {
+	if c.BucketSize == 0 {
+		c.BucketSize = 10
+	}
+	if c.RefillInterval == 0 {
+		c.RefillInterval = time.Second
+	}
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = 5
+	}
+	if c.BaseDelay == 0 {
+		c.BaseDelay = time.Second
+	}
+	if c.MaxDelay == 0 {
+		c.MaxDelay = time.Minute
+	}
+	return c
+}
+
+// limiterState is the per-(username, ip) bucket and lockout bookkeeping.
+type limiterState struct {
+	tokens      float64
+	lastRefill  time.Time
+	failures    int
+	lockedUntil time.Time
+}
+
+// memoryLoginLimiter is the default LoginLimiter: an in-memory, mutex-guarded
+// token bucket per (username, ip), with an exponential backoff lockout
+// layered on top once a pair has failed FailureThreshold times in a row.
+type memoryLoginLimiter struct {
+	mu     sync.Mutex
+	config LimiterConfig
+	state  map[string]*limiterState
+}
+
+// NewLoginLimiter builds the default LoginLimiter from cfg.
+func NewLoginLimiter(cfg LimiterConfig) LoginLimiter {
+	return &memoryLoginLimiter{
+		config: cfg.withDefaults(),
+		state:  make(map[string]*limiterState),
+	}
+}
+
+func limiterKey(username, ip string) string {
+	return username + "\x00" + ip
+}
+
+func (l *memoryLoginLimiter) Allow(username, ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	key := limiterKey(username, ip)
+	s, ok := l.state[key]
+	if !ok {
+		s = &limiterState{tokens: float64(l.config.BucketSize), lastRefill: now}
+		l.state[key] = s
+	}
+
+	if now.Before(s.lockedUntil) {
+		return false, s.lockedUntil.Sub(now)
+	}
+
+	l.refill(s, now)
+	if s.tokens < 1 {
+		return false, l.config.RefillInterval
+	}
+
+	s.tokens--
+	return true, 0
+}
+
+func (l *memoryLoginLimiter) refill(s *limiterState, now time.Time) {
+	elapsed := now.Sub(s.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+
+	added := elapsed.Seconds() / l.config.RefillInterval.Seconds()
+	if added <= 0 {
+		return
+	}
+
+	s.tokens += added
+	if s.tokens > float64(l.config.BucketSize) {
+		s.tokens = float64(l.config.BucketSize)
+	}
+	s.lastRefill = now
+}
+
+func (l *memoryLoginLimiter) RecordFailure(username, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := limiterKey(username, ip)
+	s, ok := l.state[key]
+	if !ok {
+		s = &limiterState{tokens: float64(l.config.BucketSize), lastRefill: time.Now()}
+		l.state[key] = s
+	}
+
+	s.failures++
+	if s.failures < l.config.FailureThreshold {
+		return
+	}
+
+	backoff := l.config.BaseDelay << uint(s.failures-l.config.FailureThreshold)
+	if backoff <= 0 || backoff > l.config.MaxDelay {
+		backoff = l.config.MaxDelay
+	}
+	s.lockedUntil = time.Now().Add(backoff)
+}
+
+func (l *memoryLoginLimiter) RecordSuccess(username, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.state, limiterKey(username, ip))
+}
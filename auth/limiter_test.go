@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryLoginLimiter(t *testing.T) {
+	t.Run("denies once the token bucket is empty", func(t *testing.T) {
+		limiter := NewLoginLimiter(LimiterConfig{BucketSize: 2, RefillInterval: time.Hour, FailureThreshold: 100})
+
+		for i := 0; i < 2; i++ {
+			if allowed, _ := limiter.Allow("meti", "1.2.3.4"); !allowed {
+				t.Fatalf("expected attempt %d to be allowed", i)
+			}
+		}
+
+		allowed, retryAfter := limiter.Allow("meti", "1.2.3.4")
+		if allowed {
+			t.Error("expected the bucket to be empty")
+		}
+		if retryAfter <= 0 {
+			t.Error("expected a positive retryAfter")
+		}
+	})
+
+	t.Run("locks out after FailureThreshold consecutive failures", func(t *testing.T) {
+		limiter := NewLoginLimiter(LimiterConfig{BucketSize: 1000, FailureThreshold: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+		for i := 0; i < 3; i++ {
+			limiter.RecordFailure("meti", "1.2.3.4")
+		}
+
+		allowed, retryAfter := limiter.Allow("meti", "1.2.3.4")
+		if allowed {
+			t.Error("expected lockout after reaching the failure threshold")
+		}
+		if retryAfter <= 0 {
+			t.Error("expected a positive retryAfter")
+		}
+	})
+
+	t.Run("RecordSuccess clears failures and lockout", func(t *testing.T) {
+		limiter := NewLoginLimiter(LimiterConfig{BucketSize: 1000, FailureThreshold: 2, BaseDelay: time.Minute})
+
+		limiter.RecordFailure("meti", "1.2.3.4")
+		limiter.RecordFailure("meti", "1.2.3.4")
+		limiter.RecordSuccess("meti", "1.2.3.4")
+
+		if allowed, _ := limiter.Allow("meti", "1.2.3.4"); !allowed {
+			t.Error("expected RecordSuccess to clear the lockout")
+		}
+	})
+
+	t.Run("different ips for the same username are tracked independently", func(t *testing.T) {
+		limiter := NewLoginLimiter(LimiterConfig{BucketSize: 1, RefillInterval: time.Hour})
+
+		limiter.Allow("meti", "1.1.1.1")
+
+		if allowed, _ := limiter.Allow("meti", "2.2.2.2"); !allowed {
+			t.Error("expected a different ip to have its own bucket")
+		}
+	})
+}
+
+func TestLoginRateLimiting(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a burst of wrong passwords eventually gets rate limited", func(t *testing.T) {
+		user := newTestAuthService()
+		user.Register(ctx, "meti", "meti1234")
+
+		var wg sync.WaitGroup
+		trail := 100
+		results := make(chan error, trail)
+
+		for i := 0; i < trail; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := user.Login(ctx, "meti", "wrong-password")
+				results <- err
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		rateLimited := 0
+		for err := range results {
+			if err == nil {
+				t.Error("expected every attempt with a wrong password to fail")
+				continue
+			}
+			if errors.Is(err, ErrRateLimited) {
+				rateLimited++
+			}
+		}
+
+		if rateLimited == 0 {
+			t.Error("expected the limiter to kick in under a burst of wrong passwords")
+		}
+	})
+
+	t.Run("Login returns a RateLimitedError with a positive RetryAfter once the bucket is empty", func(t *testing.T) {
+		limiter := NewLoginLimiter(LimiterConfig{BucketSize: 1, RefillInterval: time.Hour})
+		user := newTestAuthService(WithLimiter(limiter))
+		user.Register(ctx, "meti", "meti1234")
+
+		if _, err := user.Login(ctx, "meti", "wrong-password"); err == nil {
+			t.Fatal("expected the first attempt to fail with a wrong password")
+		}
+
+		_, err := user.Login(ctx, "meti", "meti1234")
+		var rlErr *RateLimitedError
+		if !errors.As(err, &rlErr) {
+			t.Fatalf("expected a *RateLimitedError, got %v", err)
+		}
+		if rlErr.RetryAfter <= 0 {
+			t.Errorf("expected a positive RetryAfter, got %v", rlErr.RetryAfter)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package auth
+
+import "github.com/MetiTamru/auth-with-test-golang-project/auth/store"
+
+// Option configures an authService at construction time. Options are applied
+// in order after NewAuthService's own defaults, so later options win.
+type Option func(*authService)
+
+// WithHasher overrides the default BcryptHasher, e.g. with PlainHasher in
+// tests so they run in milliseconds instead of seconds.
+func WithHasher(hasher PasswordHasher) Option {
+	return func(a *authService) {
+		a.hasher = hasher
+	}
+}
+
+// WithBcryptCost replaces the configured hasher with a BcryptHasher using
+// cost, so when it is combined with WithHasher the later of the two wins.
+// Tests typically pass bcrypt.MinCost to keep bcrypt itself fast.
+func WithBcryptCost(cost int) Option {
+	return func(a *authService) {
+		a.hasher = &BcryptHasher{cost: cost}
+	}
+}
+
+// WithRepository overrides the default in-memory UserRepository, e.g. with a
+// SQLite- or Postgres-backed store.UserRepository for persistence across
+// restarts.
+func WithRepository(repo store.UserRepository) Option {
+	return func(a *authService) {
+		a.repo = repo
+	}
+}
+
+// WithTokenJar overrides the default in-memory TokenJar, e.g. with a
+// FileTokenJar so logouts survive a restart.
+func WithTokenJar(jar TokenJar) Option {
+	return func(a *authService) {
+		a.jar = jar
+	}
+}
+
+// WithLimiter overrides the default LoginLimiter, e.g. to tune
+// LimiterConfig or to share one limiter across multiple AuthServices.
+func WithLimiter(limiter LoginLimiter) Option {
+	return func(a *authService) {
+		a.limiter = limiter
+	}
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func passwordUser(username string) User {
+	return User{
+		Username: username,
+		Credentials: []CredentialRecord{
+			{ID: "cred-1", Kind: "password", Fields: map[string]string{"hash": "hashed-password"}},
+		},
+	}
+}
+
+// runConformanceTests exercises the UserRepository contract against repo so
+// every backend (memory, SQLite, Postgres) is held to the same behavior.
+func runConformanceTests(t *testing.T, newRepo func() UserRepository) {
+	t.Run("Create then GetByUsername returns the stored user and credentials", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		user := passwordUser("meti")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		got, err := repo.GetByUsername(ctx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if got.Username != user.Username {
+			t.Errorf("expected username %q, got %q", user.Username, got.Username)
+		}
+		if len(got.Credentials) != 1 || got.Credentials[0].Fields["hash"] != "hashed-password" {
+			t.Errorf("expected the seeded password credential, got %+v", got.Credentials)
+		}
+	})
+
+	t.Run("Create rejects a duplicate username", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		if err := repo.Create(ctx, passwordUser("meti")); err != nil {
+			t.Fatalf("first create failed: %v", err)
+		}
+
+		err := repo.Create(ctx, passwordUser("meti"))
+		if err != ErrUserExists {
+			t.Errorf("expected ErrUserExists, got %v", err)
+		}
+	})
+
+	t.Run("GetByUsername returns ErrUserNotFound for a missing user", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		_, err := repo.GetByUsername(ctx, "no-such-user")
+		if err != ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Exists reflects whether a user was created", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		exists, err := repo.Exists(ctx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if exists {
+			t.Error("expected meti not to exist yet")
+		}
+
+		if err := repo.Create(ctx, passwordUser("meti")); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		exists, err = repo.Exists(ctx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if !exists {
+			t.Error("expected meti to exist after Create")
+		}
+	})
+
+	t.Run("AddCredential appends a credential to an existing user", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		if err := repo.Create(ctx, passwordUser("meti")); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		tokenCred := CredentialRecord{ID: "cred-2", Kind: "token", Fields: map[string]string{"hash": "token-hash"}}
+		if err := repo.AddCredential(ctx, "meti", tokenCred); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		got, err := repo.GetByUsername(ctx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(got.Credentials) != 2 {
+			t.Fatalf("expected 2 credentials, got %d", len(got.Credentials))
+		}
+	})
+
+	t.Run("AddCredential rejects a missing user", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		err := repo.AddCredential(ctx, "no-such-user", CredentialRecord{ID: "cred-1", Kind: "token"})
+		if err != ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+
+	t.Run("RemoveCredential removes exactly the named credential", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		user := passwordUser("meti")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if err := repo.AddCredential(ctx, "meti", CredentialRecord{ID: "cred-2", Kind: "token"}); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if err := repo.RemoveCredential(ctx, "meti", "cred-2"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		got, err := repo.GetByUsername(ctx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(got.Credentials) != 1 || got.Credentials[0].ID != "cred-1" {
+			t.Errorf("expected only cred-1 to remain, got %+v", got.Credentials)
+		}
+	})
+
+	t.Run("RemoveCredential does not mutate a previously returned snapshot", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		user := passwordUser("meti")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if err := repo.AddCredential(ctx, "meti", CredentialRecord{ID: "cred-2", Kind: "token"}); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		snapshot, err := repo.GetByUsername(ctx, "meti")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if err := repo.RemoveCredential(ctx, "meti", "cred-1"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if len(snapshot.Credentials) != 2 || snapshot.Credentials[0].ID != "cred-1" {
+			t.Errorf("expected the earlier snapshot to be unaffected by the later removal, got %+v", snapshot.Credentials)
+		}
+	})
+
+	t.Run("RemoveCredential errors for an unknown credential id", func(t *testing.T) {
+		repo := newRepo()
+		ctx := context.Background()
+
+		if err := repo.Create(ctx, passwordUser("meti")); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		err := repo.RemoveCredential(ctx, "meti", "no-such-credential")
+		if err != ErrCredentialNotFound {
+			t.Errorf("expected ErrCredentialNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is the default UserRepository: an in-memory map guarded by a
+// mutex. It does not survive a restart.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users: make(map[string]User),
+	}
+}
+
+func (m *MemoryStore) Create(ctx context.Context, user User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.Username]; ok {
+		return ErrUserExists
+	}
+
+	m.users[user.Username] = user
+	return nil
+}
+
+func (m *MemoryStore) GetByUsername(ctx context.Context, username string) (User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[username]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+
+	user.Credentials = append([]CredentialRecord(nil), user.Credentials...)
+	return user, nil
+}
+
+func (m *MemoryStore) Exists(ctx context.Context, username string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.users[username]
+	return ok, nil
+}
+
+func (m *MemoryStore) AddCredential(ctx context.Context, username string, cred CredentialRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[username]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	for _, existing := range user.Credentials {
+		if existing.ID == cred.ID {
+			return ErrCredentialExists
+		}
+	}
+
+	user.Credentials = append(user.Credentials, cred)
+	m.users[username] = user
+	return nil
+}
+
+func (m *MemoryStore) RemoveCredential(ctx context.Context, username, credentialID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[username]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	for i, cred := range user.Credentials {
+		if cred.ID == credentialID {
+			remaining := make([]CredentialRecord, 0, len(user.Credentials)-1)
+			remaining = append(remaining, user.Credentials[:i]...)
+			remaining = append(remaining, user.Credentials[i+1:]...)
+			user.Credentials = remaining
+			m.users[username] = user
+			return nil
+		}
+	}
+
+	return ErrCredentialNotFound
+}
@@ -0,0 +1,9 @@
+package store
+
+import "testing"
+
+func TestMemoryStore(t *testing.T) {
+	runConformanceTests(t, func() UserRepository {
+		return NewMemoryStore()
+	})
+}
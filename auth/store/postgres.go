@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// PostgresStore is a UserRepository backed by Postgres. Callers own the
+// *sql.DB (and its driver registration, e.g. lib/pq or jackc/pgx) so this
+// package stays driver-agnostic.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore wraps db. Call EnsureSchema once before first use.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the users and credentials tables if they do not
+// already exist.
+func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS users (
+			username TEXT PRIMARY KEY
+		);
+		CREATE TABLE IF NOT EXISTS credentials (
+			id       TEXT PRIMARY KEY,
+			username TEXT NOT NULL REFERENCES users(username),
+			kind     TEXT NOT NULL,
+			fields   TEXT NOT NULL
+		)
+	`)
+	return err
+}
+
+func (s *PostgresStore) Create(ctx context.Context, user User) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO users (username) VALUES ($1)`, user.Username); err != nil {
+		if isUniqueViolation(err) {
+			return ErrUserExists
+		}
+		return err
+	}
+
+	for _, cred := range user.Credentials {
+		fields, err := json.Marshal(cred.Fields)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO credentials (id, username, kind, fields) VALUES ($1, $2, $3, $4)`,
+			cred.ID, user.Username, cred.Kind, string(fields),
+		); err != nil {
+			if isUniqueViolation(err) {
+				return ErrCredentialExists
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) GetByUsername(ctx context.Context, username string) (User, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username,
+	).Scan(&exists); err != nil {
+		return User{}, err
+	}
+	if !exists {
+		return User{}, ErrUserNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, kind, fields FROM credentials WHERE username = $1`, username,
+	)
+	if err != nil {
+		return User{}, err
+	}
+	defer rows.Close()
+
+	user := User{Username: username}
+	for rows.Next() {
+		cred, err := scanCredential(rows)
+		if err != nil {
+			return User{}, err
+		}
+		user.Credentials = append(user.Credentials, cred)
+	}
+	if err := rows.Err(); err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *PostgresStore) Exists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	row := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username,
+	)
+	if err := row.Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (s *PostgresStore) AddCredential(ctx context.Context, username string, cred CredentialRecord) error {
+	exists, err := s.Exists(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	fields, err := json.Marshal(cred.Fields)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO credentials (id, username, kind, fields) VALUES ($1, $2, $3, $4)`,
+		cred.ID, username, cred.Kind, string(fields),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrCredentialExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *PostgresStore) RemoveCredential(ctx context.Context, username, credentialID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM credentials WHERE username = $1 AND id = $2`, username, credentialID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build postgres
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set, skipping Postgres conformance tests")
+	}
+
+	runConformanceTests(t, func() UserRepository {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatalf("failed to open postgres: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		// Every subtest gets empty tables, as the other backends' fresh
+		// repositories do.
+		if _, err := db.ExecContext(context.Background(), "DROP TABLE IF EXISTS credentials, users"); err != nil {
+			t.Fatalf("failed to reset schema: %v", err)
+		}
+
+		repo := NewPostgresStore(db)
+		if err := repo.EnsureSchema(context.Background()); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+		t.Cleanup(func() {
+			db.ExecContext(context.Background(), "DROP TABLE IF EXISTS credentials, users")
+		})
+		return repo
+	})
+}
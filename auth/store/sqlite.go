@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// SQLiteStore is a UserRepository backed by a SQLite database. Callers own
+// the *sql.DB (and its driver registration, e.g. mattn/go-sqlite3 or
+// modernc.org/sqlite) so this package stays driver-agnostic.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore wraps db. Call EnsureSchema once before first use.
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// EnsureSchema creates the users and credentials tables if they do not
+// already exist.
+func (s *SQLiteStore) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS users (
+			username TEXT PRIMARY KEY
+		);
+		CREATE TABLE IF NOT EXISTS credentials (
+			id       TEXT PRIMARY KEY,
+			username TEXT NOT NULL REFERENCES users(username),
+			kind     TEXT NOT NULL,
+			fields   TEXT NOT NULL
+		)
+	`)
+	return err
+}
+
+func (s *SQLiteStore) Create(ctx context.Context, user User) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO users (username) VALUES (?)`, user.Username); err != nil {
+		if isUniqueViolation(err) {
+			return ErrUserExists
+		}
+		return err
+	}
+
+	for _, cred := range user.Credentials {
+		if err := insertCredential(ctx, tx, user.Username, cred); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) GetByUsername(ctx context.Context, username string) (User, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)`, username,
+	).Scan(&exists); err != nil {
+		return User{}, err
+	}
+	if !exists {
+		return User{}, ErrUserNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, kind, fields FROM credentials WHERE username = ?`, username,
+	)
+	if err != nil {
+		return User{}, err
+	}
+	defer rows.Close()
+
+	user := User{Username: username}
+	for rows.Next() {
+		cred, err := scanCredential(rows)
+		if err != nil {
+			return User{}, err
+		}
+		user.Credentials = append(user.Credentials, cred)
+	}
+	if err := rows.Err(); err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *SQLiteStore) Exists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	row := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)`, username,
+	)
+	if err := row.Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (s *SQLiteStore) AddCredential(ctx context.Context, username string, cred CredentialRecord) error {
+	exists, err := s.Exists(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	if err := insertCredential(ctx, s.db, username, cred); err != nil {
+		if isUniqueViolation(err) {
+			return ErrCredentialExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SQLiteStore) RemoveCredential(ctx context.Context, username, credentialID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM credentials WHERE username = ? AND id = ?`, username, credentialID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertCredential(ctx context.Context, db sqlExecer, username string, cred CredentialRecord) error {
+	fields, err := json.Marshal(cred.Fields)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO credentials (id, username, kind, fields) VALUES (?, ?, ?, ?)`,
+		cred.ID, username, cred.Kind, string(fields),
+	)
+	return err
+}
+
+// sqlRowsScanner is satisfied by *sql.Rows.
+type sqlRowsScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCredential(row sqlRowsScanner) (CredentialRecord, error) {
+	var cred CredentialRecord
+	var fields string
+	if err := row.Scan(&cred.ID, &cred.Kind, &fields); err != nil {
+		return CredentialRecord{}, err
+	}
+	if err := json.Unmarshal([]byte(fields), &cred.Fields); err != nil {
+		return CredentialRecord{}, err
+	}
+	return cred, nil
+}
+
+// isUniqueViolation is a best-effort check across common SQLite and Postgres
+// drivers, none of which share a single sentinel error type for constraint
+// violations across every driver implementation.
+func isUniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") ||
+		strings.Contains(msg, "constraint failed: UNIQUE") ||
+		strings.Contains(msg, "duplicate key value violates unique constraint")
+}
@@ -0,0 +1,27 @@
+//go:build sqlite
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	runConformanceTests(t, func() UserRepository {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatalf("failed to open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		repo := NewSQLiteStore(db)
+		if err := repo.EnsureSchema(context.Background()); err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,47 @@
+// Package store provides UserRepository implementations backing authService:
+// an in-memory map (the default), and SQL-backed stores for SQLite and
+// Postgres for deployments that need persistence across restarts.
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrUserExists         = errors.New("username already exist")
+	ErrUserNotFound       = errors.New("this user does not exist")
+	ErrCredentialExists   = errors.New("credential already exists")
+	ErrCredentialNotFound = errors.New("credential does not exist")
+)
+
+// CredentialRecord is an opaque, persisted credential. Kind identifies which
+// auth.Credential it came from; Fields holds whatever that credential type
+// needs at rest (e.g. a password hash, or an OAuth provider + subject id).
+// Keeping it generic here means this package never needs to know about
+// auth.Credential's concrete types.
+type CredentialRecord struct {
+	ID     string
+	Kind   string
+	Fields map[string]string
+}
+
+// User is a persisted account. A user starts with the PasswordCredential
+// created at registration and may accumulate more; RemoveCredential can
+// remove any of them, including that one.
+type User struct {
+	Username    string
+	Credentials []CredentialRecord
+}
+
+// UserRepository is how authService persists and looks up accounts and their
+// credentials. All methods take a context so callers can bound DB
+// round-trips with a deadline or cancel them, same as the rest of
+// database/sql's API.
+type UserRepository interface {
+	Create(ctx context.Context, user User) error
+	GetByUsername(ctx context.Context, username string) (User, error)
+	Exists(ctx context.Context, username string) (bool, error)
+	AddCredential(ctx context.Context, username string, cred CredentialRecord) error
+	RemoveCredential(ctx context.Context, username, credentialID string) error
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MetiTamru/auth-with-test-golang-project/auth/store"
+)
+
+func TestWithRepositoryOption(t *testing.T) {
+	ctx := context.Background()
+	repo := store.NewMemoryStore()
+	user := newTestAuthService(WithRepository(repo))
+
+	if err := user.Register(ctx, "meti", "meti1234"); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	exists, err := repo.Exists(ctx, "meti")
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if !exists {
+		t.Error("expected the configured repository to receive the new user")
+	}
+
+	if _, err := user.Login(ctx, "meti", "meti1234"); err != nil {
+		t.Errorf("expected login against the configured repository to succeed, got %v", err)
+	}
+}
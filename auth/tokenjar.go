@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// TokenRecord is everything a TokenJar keeps about one issued token, enough
+// to answer "is this token still good" without re-deriving it from the JWT
+// itself.
+type TokenRecord struct {
+	ID        string
+	Subject   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Revoked   bool
+}
+
+// TokenJar tracks issued tokens so AuthService can revoke them server-side
+// even though the JWTs themselves are stateless. VerifyToken consults the
+// jar after the signature and expiry check pass.
+type TokenJar interface {
+	// Put records a newly issued token.
+	Put(record TokenRecord) error
+	// Get returns the record for tokenID, if any.
+	Get(tokenID string) (TokenRecord, bool, error)
+	// Revoke marks a single token as revoked.
+	Revoke(tokenID string) error
+	// RevokeAllForUser marks every still-valid token for username as revoked.
+	RevokeAllForUser(username string) error
+}
+
+// newTokenID generates a random, URL-safe token identifier for the jti claim.
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// MemoryTokenJar is the default TokenJar: an in-memory map guarded by a
+// mutex. Records do not survive a restart.
+type MemoryTokenJar struct {
+	mu      sync.Mutex
+	records map[string]TokenRecord
+}
+
+// NewMemoryTokenJar builds an empty MemoryTokenJar.
+func NewMemoryTokenJar() *MemoryTokenJar {
+	return &MemoryTokenJar{records: make(map[string]TokenRecord)}
+}
+
+func (j *MemoryTokenJar) Put(record TokenRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.records[record.ID] = record
+	return nil
+}
+
+func (j *MemoryTokenJar) Get(tokenID string) (TokenRecord, bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	record, ok := j.records[tokenID]
+	return record, ok, nil
+}
+
+func (j *MemoryTokenJar) Revoke(tokenID string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	record, ok := j.records[tokenID]
+	if !ok {
+		return nil
+	}
+	record.Revoked = true
+	j.records[tokenID] = record
+	return nil
+}
+
+func (j *MemoryTokenJar) RevokeAllForUser(username string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for id, record := range j.records {
+		if record.Subject == username {
+			record.Revoked = true
+			j.records[id] = record
+		}
+	}
+	return nil
+}
+
+// purgeExpired drops records that expired before now, keeping the jar from
+// growing without bound.
+func (j *MemoryTokenJar) purgeExpired(now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for id, record := range j.records {
+		if now.After(record.ExpiresAt) {
+			delete(j.records, id)
+		}
+	}
+}
+
+// snapshot returns a copy of every record currently held, for FileTokenJar
+// to persist.
+func (j *MemoryTokenJar) snapshot() []TokenRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	records := make([]TokenRecord, 0, len(j.records))
+	for _, record := range j.records {
+		records = append(records, record)
+	}
+	return records
+}
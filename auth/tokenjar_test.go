@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryTokenJar(t *testing.T) {
+	t.Run("Get reports false for an unknown token", func(t *testing.T) {
+		jar := NewMemoryTokenJar()
+
+		_, ok, err := jar.Get("no-such-token")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if ok {
+			t.Error("expected ok to be false for an unknown token")
+		}
+	})
+
+	t.Run("Revoke marks a put record as revoked", func(t *testing.T) {
+		jar := NewMemoryTokenJar()
+		record := TokenRecord{ID: "tok-1", Subject: "meti", ExpiresAt: time.Now().Add(time.Hour)}
+
+		if err := jar.Put(record); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if err := jar.Revoke("tok-1"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		got, ok, err := jar.Get("tok-1")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if !ok || !got.Revoked {
+			t.Errorf("expected tok-1 to be revoked, got %+v ok=%v", got, ok)
+		}
+	})
+
+	t.Run("RevokeAllForUser only revokes that user's tokens", func(t *testing.T) {
+		jar := NewMemoryTokenJar()
+		exp := time.Now().Add(time.Hour)
+
+		jar.Put(TokenRecord{ID: "tok-1", Subject: "meti", ExpiresAt: exp})
+		jar.Put(TokenRecord{ID: "tok-2", Subject: "meti", ExpiresAt: exp})
+		jar.Put(TokenRecord{ID: "tok-3", Subject: "other", ExpiresAt: exp})
+
+		if err := jar.RevokeAllForUser("meti"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		for _, id := range []string{"tok-1", "tok-2"} {
+			got, _, _ := jar.Get(id)
+			if !got.Revoked {
+				t.Errorf("expected %s to be revoked", id)
+			}
+		}
+
+		got, _, _ := jar.Get("tok-3")
+		if got.Revoked {
+			t.Error("expected tok-3 to remain unrevoked")
+		}
+	})
+
+	t.Run("purgeExpired drops only expired records", func(t *testing.T) {
+		jar := NewMemoryTokenJar()
+		now := time.Now()
+
+		jar.Put(TokenRecord{ID: "expired", ExpiresAt: now.Add(-time.Minute)})
+		jar.Put(TokenRecord{ID: "still-valid", ExpiresAt: now.Add(time.Hour)})
+
+		jar.purgeExpired(now)
+
+		if _, ok, _ := jar.Get("expired"); ok {
+			t.Error("expected the expired record to be purged")
+		}
+		if _, ok, _ := jar.Get("still-valid"); !ok {
+			t.Error("expected the still-valid record to remain")
+		}
+	})
+}
+
+func TestAuthServiceRevocation(t *testing.T) {
+	t.Run("a revoked token fails verification", func(t *testing.T) {
+		user := newTestAuthService()
+		token := mustLogin(t, user, "meti", "meti1234")
+
+		claims, err := user.VerifyToken(token)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if err := user.Revoke(claims.Jti); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if _, err := user.VerifyToken(token); err != ErrTokenRevoked {
+			t.Errorf("expected ErrTokenRevoked, got %v", err)
+		}
+	})
+
+	t.Run("RevokeAllForUser revokes every token for that user but not others", func(t *testing.T) {
+		user := newTestAuthService()
+		tokenA := mustLogin(t, user, "meti", "meti1234")
+		tokenB := mustLogin(t, user, "meti", "meti1234")
+		user.Register(testCtx, "other", "other1234")
+		tokenC := mustLogin(t, user, "other", "other1234")
+
+		if err := user.RevokeAllForUser("meti"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if _, err := user.VerifyToken(tokenA); err != ErrTokenRevoked {
+			t.Errorf("expected ErrTokenRevoked for tokenA, got %v", err)
+		}
+		if _, err := user.VerifyToken(tokenB); err != ErrTokenRevoked {
+			t.Errorf("expected ErrTokenRevoked for tokenB, got %v", err)
+		}
+		if _, err := user.VerifyToken(tokenC); err != nil {
+			t.Errorf("expected tokenC to still verify, got %v", err)
+		}
+	})
+}